@@ -0,0 +1,102 @@
+/*
+Copyright 2023 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sparseindex
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+
+	"github.com/openGemini/openGemini/lib/record"
+	"github.com/openGemini/openGemini/open_src/influx/influxql"
+)
+
+// bloomProbeCount is the number of bit positions tested per lookup.
+const bloomProbeCount = 4
+
+// BloomFilterEvaluator is a SkipIndexEvaluator that prunes granules using a
+// per-granule bloom filter footer, for equality predicates on columns that
+// aren't part of the primary key (and so aren't covered by KeyCondition's
+// own range pruning).
+type BloomFilterEvaluator struct {
+	colName string
+	key     []byte
+}
+
+func NewBloomFilterEvaluator() *BloomFilterEvaluator {
+	return &BloomFilterEvaluator{}
+}
+
+func (e *BloomFilterEvaluator) Name() string {
+	return "bloom"
+}
+
+func (e *BloomFilterEvaluator) Prepare(cond influxql.Expr, schema record.Schemas) (SkipIndexEvaluator, error) {
+	bin, ok := cond.(*influxql.BinaryExpr)
+	if !ok || bin.Op != influxql.EQ {
+		return nil, nil
+	}
+	ref, ok := bin.LHS.(*influxql.VarRef)
+	if !ok || schema.FieldIndex(ref.Val) < 0 {
+		return nil, nil
+	}
+	key, ok := literalBytes(bin.RHS)
+	if !ok {
+		return nil, nil
+	}
+	return &BloomFilterEvaluator{colName: ref.Val, key: key}, nil
+}
+
+func (e *BloomFilterEvaluator) MayContain(stats GranuleStats) (Mark, error) {
+	footer, ok := stats.Bloom[e.colName]
+	if !ok {
+		return NewMark(true, true), nil
+	}
+	if bloomMayContain(footer, e.key) {
+		return NewMark(true, true), nil
+	}
+	return NewMark(false, true), nil
+}
+
+// bloomMayContain tests key against a bloom filter footer: a little-endian
+// uint32 bit count followed by the packed bit array. It stays conservative
+// (reports true) for a footer too short to hold a bit count.
+func bloomMayContain(footer []byte, key []byte) bool {
+	if len(footer) < 4 {
+		return true
+	}
+	bits := binary.LittleEndian.Uint32(footer[:4])
+	data := footer[4:]
+	if bits == 0 || uint32(len(data)*8) < bits {
+		return true
+	}
+	h1, h2 := bloomHashes(key)
+	for i := uint32(0); i < bloomProbeCount; i++ {
+		bit := (h1 + i*h2) % bits
+		if data[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func bloomHashes(key []byte) (uint32, uint32) {
+	h1 := fnv.New32a()
+	_, _ = h1.Write(key)
+	h2 := fnv.New32()
+	_, _ = h2.Write(key)
+	return h1.Sum32(), h2.Sum32()
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sparseindex
+
+import (
+	"github.com/openGemini/openGemini/lib/record"
+	"github.com/openGemini/openGemini/open_src/influx/influxql"
+)
+
+// detectMonotonicChain walks an expression looking for a chain of supported
+// functions wrapping a single primary-key column, e.g. `toDate(ts)` or
+// `floor(x / 60)`. It returns the index of the wrapped primary-key column and
+// the chain of FunctionBase descriptors ordered outermost-first (chain[0] is
+// the function closest to the comparison operator). applyChainToRange folds
+// this chain from the end, i.e. innermost function first, since that is the
+// function actually touching the raw key value.
+func detectMonotonicChain(expr influxql.Expr, pkSchema record.Schemas) (int, []*FunctionBase, bool) {
+	call, ok := expr.(*influxql.Call)
+	if !ok {
+		return 0, nil, false
+	}
+	return detectCall(call, pkSchema)
+}
+
+func detectCall(call *influxql.Call, pkSchema record.Schemas) (int, []*FunctionBase, bool) {
+	if len(call.Args) == 0 {
+		return 0, nil, false
+	}
+	switch arg := call.Args[0].(type) {
+	case *influxql.VarRef:
+		idx := pkSchema.FieldIndex(arg.Val)
+		if idx < 0 {
+			return 0, nil, false
+		}
+		fb, ok := lookupFunction(call.Name, call.Args[1:])
+		if !ok {
+			return 0, nil, false
+		}
+		return idx, []*FunctionBase{fb}, true
+
+	case *influxql.Call:
+		idx, chain, ok := detectCall(arg, pkSchema)
+		if !ok {
+			return 0, nil, false
+		}
+		fb, ok := lookupFunction(call.Name, call.Args[1:])
+		if !ok {
+			return 0, nil, false
+		}
+		return idx, append([]*FunctionBase{fb}, chain...), true
+
+	case *influxql.BinaryExpr:
+		// The only binary shape this chain analysis understands is the
+		// `key / const` division idiom used by time-bucketing helpers such
+		// as `floor(x / 60)` and `mod(x, 60)` (the latter parsed with the
+		// divisor as a direct argument, handled by the VarRef case above).
+		idx, divisor, ok := detectKeyDivConst(arg, pkSchema)
+		if !ok {
+			return 0, nil, false
+		}
+		fb, ok := lookupFunction(call.Name, []influxql.Expr{&influxql.IntegerLiteral{Val: divisor}})
+		if !ok {
+			return 0, nil, false
+		}
+		return idx, []*FunctionBase{fb}, true
+
+	default:
+		return 0, nil, false
+	}
+}
+
+// detectKeyDivConst recognizes `<primary-key column> / <integer literal>`.
+func detectKeyDivConst(expr *influxql.BinaryExpr, pkSchema record.Schemas) (int, int64, bool) {
+	if expr.Op != influxql.DIV {
+		return 0, 0, false
+	}
+	varRef, ok := expr.LHS.(*influxql.VarRef)
+	if !ok {
+		return 0, 0, false
+	}
+	lit, ok := expr.RHS.(*influxql.IntegerLiteral)
+	if !ok {
+		return 0, 0, false
+	}
+	idx := pkSchema.FieldIndex(varRef.Val)
+	if idx < 0 {
+		return 0, 0, false
+	}
+	return idx, lit.Val, true
+}
@@ -46,6 +46,12 @@ func NewKeyCondition(timeCondition, condition influxql.Expr, pkSchema record.Sch
 	cols := genIndexColumnsBySchema(pkSchema)
 	// use "AND" to connect the time condition to other conditions.
 	combineCondition := binaryfilterfunc.CombineConditionWithAnd(timeCondition, condition)
+	// rpn.ConvertToRPNExpr has no notion of NOT, so it must never see one:
+	// push every NOT down to its leaves and eliminate it first.
+	combineCondition = normalizeNot(combineCondition)
+	if containsNot(combineCondition) {
+		return nil, errno.NewError(errno.ErrRPNOp, influxql.NOT)
+	}
 	rpnExpr := rpn.ConvertToRPNExpr(combineCondition)
 	if err := kc.convertToRPNElem(rpnExpr, cols); err != nil {
 		return nil, err
@@ -74,7 +80,8 @@ func (kc *KeyConditionImpl) convertToRPNElem(
 				} else {
 					fieldCount--
 				}
-			case influxql.EQ, influxql.LT, influxql.LTE, influxql.GT, influxql.GTE, influxql.NEQ:
+			case influxql.EQ, influxql.LT, influxql.LTE, influxql.GT, influxql.GTE, influxql.NEQ, influxql.IN, influxql.NIN,
+				influxql.EQREGEX, influxql.LIKE:
 			default:
 				return errno.NewError(errno.ErrRPNOp, v)
 			}
@@ -88,18 +95,50 @@ func (kc *KeyConditionImpl) convertToRPNElem(
 				fieldCount++
 				continue
 			}
-			if i+2 >= len(rpnExpr.Val) {
-				return errno.NewError(errno.ErrRPNElemNum)
+			value, op, err := rpnTriplet(rpnExpr, i)
+			if err != nil {
+				return err
+			}
+			if err := kc.dispatchRPNElem([]int{idx}, value, op, cols, nil); err != nil {
+				return err
+			}
+		case *influxql.Call:
+			if v.Name == "starts_with" {
+				handled, err := kc.handleStartsWith(v, cols)
+				if err != nil {
+					return err
+				}
+				if !handled {
+					fieldCount++
+				}
+				continue
 			}
-			value := rpnExpr.Val[i+1]
-			op, ok := rpnExpr.Val[i+2].(influxql.Token)
+			idx, chain, ok := detectMonotonicChain(v, kc.pkSchema)
 			if !ok {
-				return errno.NewError(errno.ErrRPNElemOp)
+				fieldCount++
+				continue
+			}
+			value, op, err := rpnTriplet(rpnExpr, i)
+			if err != nil {
+				return err
 			}
-			if err := kc.genRPNElementByVal(value, op, cols, idx); err != nil {
+			if err := kc.dispatchRPNElem([]int{idx}, value, op, cols, chain); err != nil {
 				return err
 			}
-		case *influxql.StringLiteral, *influxql.NumberLiteral, *influxql.IntegerLiteral, *influxql.BooleanLiteral:
+		case *influxql.Tuple:
+			idxs, ok := kc.resolveTupleIndices(v)
+			if !ok {
+				fieldCount++
+				continue
+			}
+			value, op, err := rpnTriplet(rpnExpr, i)
+			if err != nil {
+				return err
+			}
+			if err := kc.dispatchRPNElem(idxs, value, op, cols, nil); err != nil {
+				return err
+			}
+		case *influxql.StringLiteral, *influxql.NumberLiteral, *influxql.IntegerLiteral, *influxql.BooleanLiteral, *influxql.ListLiteral:
 		default:
 			return errno.NewError(errno.ErrRPNExpr, v)
 		}
@@ -112,9 +151,31 @@ func (kc *KeyConditionImpl) genRPNElementByVal(
 	op influxql.Token,
 	cols []*ColumnRef,
 	idx int,
+	chain []*FunctionBase,
 ) error {
-	rpnElem := &RPNElement{keyColumn: idx}
+	rpnElem := &RPNElement{keyColumn: idx, monotonicChains: chain}
 	value := NewFieldRef(cols, idx, 0)
+	// toDate's Apply maps the key column's range into an integer-nanosecond
+	// domain, so a string RHS like '2024-01-01' must be parsed into that same
+	// domain before it's stored, or the later intersectsRange/containsRange
+	// check ends up comparing a string literal against an integer range.
+	if len(chain) > 0 && chain[0].Name == "toDate" {
+		if lit, ok := rhs.(*influxql.StringLiteral); ok {
+			ns, ok := parseDateLiteral(lit.Val)
+			if !ok {
+				// Not a parseable date: there is nothing safe to prune on,
+				// but an AND/OR sibling still expects this conjunct to push
+				// exactly one Mark, so fall back to an always-true whole
+				// range instead of silently dropping the element (which
+				// would underflow the RPN evaluation stack).
+				rpnElem.op = rpn.InRange
+				rpnElem.rg = createWholeRangeWithoutBound()
+				kc.rpn = append(kc.rpn, rpnElem)
+				return nil
+			}
+			rhs = &influxql.IntegerLiteral{Val: ns}
+		}
+	}
 	switch rhs := rhs.(type) {
 	case *influxql.StringLiteral:
 		value.cols[idx].column.AppendString(rhs.Val)
@@ -136,14 +197,88 @@ func (kc *KeyConditionImpl) genRPNElementByVal(
 	return nil
 }
 
-// applyChainToRange apply the monotonicity of each function on a specific range.
+// rpnTriplet reads the value/operator pair immediately following a field at
+// position i in rpnExpr.Val, i.e. the `'a' =` in the `(A 'a' =)` triplet noted
+// above.
+func rpnTriplet(rpnExpr *rpn.RPNExpr, i int) (interface{}, influxql.Token, error) {
+	if i+2 >= len(rpnExpr.Val) {
+		return nil, 0, errno.NewError(errno.ErrRPNElemNum)
+	}
+	value := rpnExpr.Val[i+1]
+	op, ok := rpnExpr.Val[i+2].(influxql.Token)
+	if !ok {
+		return nil, 0, errno.NewError(errno.ErrRPNElemOp)
+	}
+	return value, op, nil
+}
+
+// resolveTupleIndices maps each VarRef of a `(a, b) IN (...)` left-hand-side
+// tuple to its primary-key schema index, or reports false if any element
+// isn't itself a primary-key column.
+func (kc *KeyConditionImpl) resolveTupleIndices(t *influxql.Tuple) ([]int, bool) {
+	idxs := make([]int, 0, len(t.Exprs))
+	for _, e := range t.Exprs {
+		ref, ok := e.(*influxql.VarRef)
+		if !ok {
+			return nil, false
+		}
+		idx := kc.pkSchema.FieldIndex(ref.Val)
+		if idx < 0 {
+			return nil, false
+		}
+		idxs = append(idxs, idx)
+	}
+	return idxs, true
+}
+
+// dispatchRPNElem routes a resolved key expression to either the range path
+// (single column, comparison operator, possibly behind a monotonic chain) or
+// the set path (IN/NOT IN, one or more columns).
+func (kc *KeyConditionImpl) dispatchRPNElem(
+	idxs []int,
+	value interface{},
+	op influxql.Token,
+	cols []*ColumnRef,
+	chain []*FunctionBase,
+) error {
+	if op == influxql.IN || op == influxql.NIN {
+		return kc.genRPNElementForSet(idxs, value, op, cols)
+	}
+	if len(idxs) != 1 {
+		return errno.NewError(errno.ErrRPNOp, op)
+	}
+	if op == influxql.EQREGEX || op == influxql.LIKE {
+		return kc.genRPNElementForPattern(idxs[0], value, op, cols)
+	}
+	return kc.genRPNElementByVal(value, op, cols, idxs[0], chain)
+}
+
+// applyChainToRange applies the monotonicity of each function on a specific range.
+// chain is ordered outermost-first, but the chain must be folded starting from
+// the innermost function, the one that actually operates on the raw key
+// value, so it walks chain right-to-left. As soon as a function is not
+// monotonic on the sub-range it has been narrowed to (or declines to map it),
+// pruning can't be trusted any further and the whole, unrestricted range is
+// returned so the caller stays conservative.
 func (kc *KeyConditionImpl) applyChainToRange(
-	_ *Range,
-	_ []*FunctionBase,
-	_ int,
-	_ bool,
+	keyRange *Range,
+	chain []*FunctionBase,
+	dataType int,
+	singlePoint bool,
 ) *Range {
-	return &Range{}
+	rg := keyRange
+	for i := len(chain) - 1; i >= 0; i-- {
+		fb := chain[i]
+		if !singlePoint && fb.Monotonicity(dataType) == NonMonotonic {
+			return createWholeRangeWithoutBound()
+		}
+		newRg := fb.Apply(rg, dataType)
+		if newRg == nil {
+			return createWholeRangeWithoutBound()
+		}
+		rg = newRg
+	}
+	return rg
 }
 
 // CheckInRange check Whether the condition and its negation are feasible
@@ -192,12 +327,11 @@ func (kc *KeyConditionImpl) checkInRangeForRange(
 ) []Mark {
 	keyRange := rgs[elem.keyColumn]
 	if len(elem.monotonicChains) > 0 {
-		newRange := kc.applyChainToRange(keyRange, elem.monotonicChains, dataTypes[elem.keyColumn], singlePoint)
-		if newRange != nil {
-			rpnStack = append(rpnStack, NewMark(true, true))
-			return rpnStack
-		}
-		keyRange = newRange
+		// applyChainToRange maps keyRange through the function chain. When a
+		// function in the chain isn't monotonic on the sub-range it sees, it
+		// degenerates to the whole axis, which keeps the intersects/contains
+		// check below conservative instead of wrongly pruning the granule.
+		keyRange = kc.applyChainToRange(keyRange, elem.monotonicChains, dataTypes[elem.keyColumn], singlePoint)
 	}
 	intersects := elem.rg.intersectsRange(keyRange)
 	contains := elem.rg.containsRange(keyRange)
@@ -430,6 +564,18 @@ func (kc *KeyConditionImpl) MayBeInRange(
 	return mark.canBeTrue, nil
 }
 
+// RequiresResidualFilter reports whether any element of the condition was
+// synthesized from a pattern whose range can only prune granules, not prove
+// the predicate, so the caller must still re-evaluate it on matching rows.
+func (kc *KeyConditionImpl) RequiresResidualFilter() bool {
+	for _, elem := range kc.rpn {
+		if elem.residualFilterRequired {
+			return true
+		}
+	}
+	return false
+}
+
 func (kc *KeyConditionImpl) HavePrimaryKey() bool {
 	return len(kc.rpn) > 0
 }
@@ -0,0 +1,90 @@
+/*
+Copyright 2023 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sparseindex
+
+import (
+	"github.com/openGemini/openGemini/lib/rpn"
+	"github.com/openGemini/openGemini/open_src/influx/influxql"
+)
+
+// SetIndex is implemented by the element stored for rpn.InSet/rpn.NotInSet
+// RPN elements. It lets CheckInRange test a hyper-rectangle against a whole
+// set of key tuples without expanding the set into ORed equalities. Whether
+// the predicate is IN or NOT IN is carried by the RPNElement's op
+// (rpn.InSet/rpn.NotInSet), not by the index itself.
+type SetIndex interface {
+	// Not reports whether the index failed to build and must not be used.
+	Not() bool
+	checkInRange(rgs []*Range, dataTypes []int, singlePoint bool) Mark
+}
+
+// RPNElement is a single node of the reverse-polish-notation form of a
+// KeyCondition. Elements with op <= rpn.NotInSet carry a keyColumn and are
+// evaluated against the range/set for that column; AND/OR elements combine
+// the marks already pushed onto the evaluation stack.
+type RPNElement struct {
+	op rpn.Op
+
+	// keyColumn is the index of the primary-key column this element tests.
+	keyColumn int
+
+	// rg is populated for rpn.InRange/rpn.NotInRange elements.
+	rg *Range
+
+	// setIndex is populated for rpn.InSet/rpn.NotInSet elements.
+	setIndex SetIndex
+
+	// monotonicChains holds the chain of functions wrapping the key column,
+	// outermost first, e.g. for `toDate(floor(ts/60))` it is
+	// [toDate, floor]. Empty when the predicate applies directly to the key.
+	monotonicChains []*FunctionBase
+
+	// residualFilterRequired is set for a prefix range synthesized from a
+	// LIKE/regex predicate whose pattern has more to it than the prefix
+	// itself, e.g. `name LIKE 'foo%bar'`. The range alone can prune
+	// granules but can't prove the predicate, so the caller must still
+	// evaluate it row-by-row on anything the range lets through.
+	residualFilterRequired bool
+}
+
+// genRPNElementByOp fills in rpnElem.rg from a single comparison operator and
+// returns whether the element should be kept.
+func genRPNElementByOp(op influxql.Token, value *FieldRef, rpnElem *RPNElement) bool {
+	switch op {
+	case influxql.EQ:
+		rpnElem.op = rpn.InRange
+		rpnElem.rg = NewRange(value, value, true, true)
+	case influxql.NEQ:
+		rpnElem.op = rpn.NotInRange
+		rpnElem.rg = NewRange(value, value, true, true)
+	case influxql.LT:
+		rpnElem.op = rpn.InRange
+		rpnElem.rg = createRightBounded(value, false, false)
+	case influxql.LTE:
+		rpnElem.op = rpn.InRange
+		rpnElem.rg = createRightBounded(value, true, false)
+	case influxql.GT:
+		rpnElem.op = rpn.InRange
+		rpnElem.rg = createLeftBounded(value, false, false)
+	case influxql.GTE:
+		rpnElem.op = rpn.InRange
+		rpnElem.rg = createLeftBounded(value, true, false)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2023 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sparseindex
+
+import (
+	"time"
+
+	"github.com/openGemini/openGemini/open_src/influx/influxql"
+	"github.com/openGemini/openGemini/open_src/vm/protoparser/influx"
+)
+
+// secondsPerDay is used by the toDate chain function to collapse a
+// nanosecond-precision timestamp range to whole-day boundaries.
+const secondsPerDay = 24 * 60 * 60
+
+// Monotonicity describes how a FunctionBase's output moves as its input
+// moves across a range, which determines whether an interval can be mapped
+// through it without widening to the whole range.
+type Monotonicity int
+
+const (
+	NonMonotonic Monotonicity = iota
+	StrictlyIncreasing
+	NonDecreasing
+	StrictlyDecreasing
+)
+
+// FunctionBase describes one link in a monotonic function chain wrapping a
+// primary-key column, e.g. the `toDate` in `toDate(ts) = '2024-01-01'`.
+// Apply maps an input Range (expressed in terms of the function's argument)
+// to the corresponding output Range (expressed in terms of the function's
+// result), or returns nil if the function is not monotonic on that input,
+// in which case the caller must fall back to the unrestricted range.
+type FunctionBase struct {
+	Name string
+
+	// monotonic reports the function's monotonicity for the given argument
+	// data type. Functions that are piecewise monotonic (e.g. negate on an
+	// unsigned type) return NonMonotonic here so callers stay conservative.
+	monotonic func(dataType int) Monotonicity
+
+	// Apply maps rg, a range of the function's input, to a range of its
+	// output. It returns nil when the mapping cannot be computed precisely
+	// on rg (e.g. an open bound it doesn't know how to project).
+	Apply func(rg *Range, dataType int) *Range
+}
+
+func (fb *FunctionBase) Monotonicity(dataType int) Monotonicity {
+	return fb.monotonic(dataType)
+}
+
+// functionRegistry maps a call name to a constructor that builds the
+// FunctionBase for that call, given its non-key arguments (e.g. the divisor
+// in mod(x, 60) or the offset/length in substr(name, 0, 3)).
+var functionRegistry = map[string]func(args []influxql.Expr) (*FunctionBase, bool){
+	"toDate":      newToDateFunction,
+	"negate":      newNegateFunction,
+	"mod":         newModFunction,
+	"floor":       newFloorFunction,
+	"substr":      newSubstrFunction,
+	"starts_with": newStartsWithFunction,
+}
+
+// lookupFunction resolves a call name plus its non-key arguments to a
+// FunctionBase, or false if the function isn't registered for pruning.
+func lookupFunction(name string, args []influxql.Expr) (*FunctionBase, bool) {
+	ctor, ok := functionRegistry[name]
+	if !ok {
+		return nil, false
+	}
+	return ctor(args)
+}
+
+func newToDateFunction([]influxql.Expr) (*FunctionBase, bool) {
+	return &FunctionBase{
+		Name:      "toDate",
+		monotonic: func(int) Monotonicity { return NonDecreasing },
+		Apply: func(rg *Range, dataType int) *Range {
+			if dataType != influx.Field_Type_Int {
+				return nil
+			}
+			return &Range{
+				left:         truncateToDay(rg.left),
+				right:        truncateToDay(rg.right),
+				includeLeft:  true,
+				includeRight: true,
+			}
+		},
+	}, true
+}
+
+// dateLayout is the literal format a toDate comparison's RHS must parse as
+// for parseDateLiteral to place it in the same integer-nanosecond domain
+// newToDateFunction's Apply produces.
+const dateLayout = "2006-01-02"
+
+// parseDateLiteral parses a 'YYYY-MM-DD' date literal into the same
+// nanosecond-since-epoch domain Apply produces, so a predicate such as
+// toDate(ts) = '2024-01-01' can be pruned by comparing an integer range
+// against an integer literal instead of an integer range against the raw
+// string literal.
+func parseDateLiteral(s string) (int64, bool) {
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return 0, false
+	}
+	return t.UnixNano(), true
+}
+
+// truncateToDay returns the start of the UTC day containing f's nanosecond
+// timestamp, rounding toward negative infinity for pre-epoch values.
+func truncateToDay(f *FieldRef) *FieldRef {
+	if f == nil {
+		return nil
+	}
+	ts, ok := f.IntegerValue()
+	if !ok {
+		return nil
+	}
+	const nsPerDay = secondsPerDay * int64(1e9)
+	return newIntegerFieldRef(floorDiv(ts, nsPerDay) * nsPerDay)
+}
+
+// newNegateFunction builds the FunctionBase for unary negation, which is
+// strictly decreasing on signed types and swaps+inverts its bounds.
+func newNegateFunction([]influxql.Expr) (*FunctionBase, bool) {
+	return &FunctionBase{
+		Name: "negate",
+		monotonic: func(dataType int) Monotonicity {
+			if dataType == influx.Field_Type_Int || dataType == influx.Field_Type_Float {
+				return StrictlyDecreasing
+			}
+			return NonMonotonic
+		},
+		Apply: func(rg *Range, dataType int) *Range {
+			switch dataType {
+			case influx.Field_Type_Int:
+				return negateIntRange(rg)
+			case influx.Field_Type_Float:
+				return negateFloatRange(rg)
+			default:
+				return nil
+			}
+		},
+	}, true
+}
+
+func negateIntRange(rg *Range) *Range {
+	out := &Range{includeLeft: rg.includeRight, includeRight: rg.includeLeft}
+	if rg.right != nil {
+		v, ok := rg.right.IntegerValue()
+		if !ok {
+			return nil
+		}
+		out.left = newIntegerFieldRef(-v)
+	}
+	if rg.left != nil {
+		v, ok := rg.left.IntegerValue()
+		if !ok {
+			return nil
+		}
+		out.right = newIntegerFieldRef(-v)
+	}
+	return out
+}
+
+func negateFloatRange(rg *Range) *Range {
+	out := &Range{includeLeft: rg.includeRight, includeRight: rg.includeLeft}
+	if rg.right != nil {
+		v, ok := rg.right.FloatValue()
+		if !ok {
+			return nil
+		}
+		out.left = newFloatFieldRef(-v)
+	}
+	if rg.left != nil {
+		v, ok := rg.left.FloatValue()
+		if !ok {
+			return nil
+		}
+		out.right = newFloatFieldRef(-v)
+	}
+	return out
+}
+
+// newModFunction builds the FunctionBase for `mod(x, n)`. mod is periodic,
+// hence non-monotonic, so Apply always degenerates the range to the whole
+// axis, whatever the width of the input range.
+func newModFunction(args []influxql.Expr) (*FunctionBase, bool) {
+	divisor, ok := integerLiteralArg(args, 0)
+	if !ok || divisor == 0 {
+		return nil, false
+	}
+	return &FunctionBase{
+		Name:      "mod",
+		monotonic: func(int) Monotonicity { return NonMonotonic },
+		Apply: func(*Range, int) *Range {
+			// mod wraps around every `divisor`, so the input bounds are not
+			// projected; the whole axis keeps pruning safe.
+			return createWholeRangeWithoutBound()
+		},
+	}, true
+}
+
+// newFloorFunction builds the FunctionBase for `floor(x / n)`, the common
+// time-bucketing idiom. It is non-decreasing in x for a positive divisor, so
+// an input range maps to the range of bucket indices it can fall into.
+func newFloorFunction(args []influxql.Expr) (*FunctionBase, bool) {
+	divisor, ok := integerLiteralArg(args, 0)
+	if !ok || divisor <= 0 {
+		return nil, false
+	}
+	return &FunctionBase{
+		Name:      "floor",
+		monotonic: func(int) Monotonicity { return NonDecreasing },
+		Apply: func(rg *Range, dataType int) *Range {
+			if dataType != influx.Field_Type_Int {
+				return nil
+			}
+			out := &Range{includeLeft: true, includeRight: true}
+			if rg.left != nil {
+				v, ok := rg.left.IntegerValue()
+				if !ok {
+					return nil
+				}
+				out.left = newIntegerFieldRef(floorDiv(v, divisor))
+			}
+			if rg.right != nil {
+				v, ok := rg.right.IntegerValue()
+				if !ok {
+					return nil
+				}
+				out.right = newIntegerFieldRef(floorDiv(v, divisor))
+			}
+			return out
+		},
+	}, true
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+// newSubstrFunction builds the FunctionBase for `substr(name, offset, len)`
+// at offset 0, which is non-decreasing in its string argument: it only ever
+// drops a common suffix, never reorders two strings relative to each other.
+// Any other offset cannot be pruned precisely, so it is left unregistered.
+func newSubstrFunction(args []influxql.Expr) (*FunctionBase, bool) {
+	offset, ok := integerLiteralArg(args, 0)
+	if !ok || offset != 0 {
+		return nil, false
+	}
+	length, ok := integerLiteralArg(args, 1)
+	if !ok || length <= 0 {
+		return nil, false
+	}
+	return &FunctionBase{
+		Name: "substr",
+		monotonic: func(dataType int) Monotonicity {
+			if dataType == influx.Field_Type_String {
+				return NonDecreasing
+			}
+			return NonMonotonic
+		},
+		Apply: func(rg *Range, dataType int) *Range {
+			if dataType != influx.Field_Type_String {
+				return nil
+			}
+			out := &Range{includeLeft: true, includeRight: true}
+			if rg.left != nil {
+				v, ok := rg.left.StringValue()
+				if !ok {
+					return nil
+				}
+				out.left = newStringFieldRef(truncateString(v, int(length)))
+			}
+			if rg.right != nil {
+				v, ok := rg.right.StringValue()
+				if !ok {
+					return nil
+				}
+				out.right = newStringFieldRef(truncateString(v, int(length)))
+			}
+			return out
+		},
+	}, true
+}
+
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// newStartsWithFunction is registered so detectMonotonicChain accepts a chain
+// that nests starts_with over a key column. A bare starts_with predicate is
+// handled by convertToRPNElem through handleStartsWith and successorString,
+// not through Apply; inside a chain it is NonMonotonic and Apply returns nil,
+// so such a chain always maps to the whole range.
+func newStartsWithFunction([]influxql.Expr) (*FunctionBase, bool) {
+	return &FunctionBase{
+		Name:      "starts_with",
+		monotonic: func(int) Monotonicity { return NonMonotonic },
+		Apply: func(*Range, int) *Range {
+			return nil
+		},
+	}, true
+}
+
+func integerLiteralArg(args []influxql.Expr, i int) (int64, bool) {
+	if i >= len(args) {
+		return 0, false
+	}
+	lit, ok := args[i].(*influxql.IntegerLiteral)
+	if !ok {
+		return 0, false
+	}
+	return lit.Val, true
+}
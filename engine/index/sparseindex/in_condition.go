@@ -0,0 +1,101 @@
+/*
+Copyright 2023 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sparseindex
+
+import (
+	"github.com/openGemini/openGemini/lib/errno"
+	"github.com/openGemini/openGemini/lib/rpn"
+	"github.com/openGemini/openGemini/open_src/influx/influxql"
+)
+
+// genRPNElementForSet builds a single InSet/NotInSet RPNElement backed by a
+// PKSetIndex from the RHS of an IN/NOT IN predicate, covering both the
+// single-column case `a IN (1, 2, 3)` and the tuple-in-tuple case
+// `(a, b) IN ((1, 2), (3, 4))`.
+func (kc *KeyConditionImpl) genRPNElementForSet(
+	idxs []int,
+	value interface{},
+	op influxql.Token,
+	cols []*ColumnRef,
+) error {
+	list, ok := value.(*influxql.ListLiteral)
+	if !ok {
+		return errno.NewError(errno.ErrRPNElement, value)
+	}
+	tuples := make([][]*FieldRef, 0, len(list.Vals))
+	for _, v := range list.Vals {
+		tuple, err := buildSetTuple(v, idxs, cols)
+		if err != nil {
+			return err
+		}
+		tuples = append(tuples, tuple)
+	}
+
+	rpnElem := &RPNElement{keyColumn: idxs[0], setIndex: NewPKSetIndex(idxs, tuples)}
+	if op == influxql.IN {
+		rpnElem.op = rpn.InSet
+	} else {
+		rpnElem.op = rpn.NotInSet
+	}
+	kc.rpn = append(kc.rpn, rpnElem)
+	return nil
+}
+
+// buildSetTuple parses one element of the IN list into a tuple of FieldRefs,
+// one per key column in idxs order.
+func buildSetTuple(v influxql.Expr, idxs []int, cols []*ColumnRef) ([]*FieldRef, error) {
+	if len(idxs) == 1 {
+		ref, err := appendSetLiteral(v, cols, idxs[0])
+		if err != nil {
+			return nil, err
+		}
+		return []*FieldRef{ref}, nil
+	}
+	tuple, ok := v.(*influxql.Tuple)
+	if !ok || len(tuple.Exprs) != len(idxs) {
+		return nil, errno.NewError(errno.ErrRPNElement, v)
+	}
+	refs := make([]*FieldRef, len(idxs))
+	for i, e := range tuple.Exprs {
+		ref, err := appendSetLiteral(e, cols, idxs[i])
+		if err != nil {
+			return nil, err
+		}
+		refs[i] = ref
+	}
+	return refs, nil
+}
+
+// appendSetLiteral appends a single literal to the shared key column cols[idx]
+// and returns a FieldRef pointing at the row it landed on.
+func appendSetLiteral(v influxql.Expr, cols []*ColumnRef, idx int) (*FieldRef, error) {
+	col := cols[idx]
+	row := col.column.Len
+	switch lit := v.(type) {
+	case *influxql.StringLiteral:
+		col.column.AppendString(lit.Val)
+	case *influxql.NumberLiteral:
+		col.column.AppendFloat(lit.Val)
+	case *influxql.IntegerLiteral:
+		col.column.AppendInteger(lit.Val)
+	case *influxql.BooleanLiteral:
+		col.column.AppendBoolean(lit.Val)
+	default:
+		return nil, errno.NewError(errno.ErrRPNElement, v)
+	}
+	return NewFieldRef(cols, idx, row), nil
+}
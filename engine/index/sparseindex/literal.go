@@ -0,0 +1,63 @@
+/*
+Copyright 2023 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sparseindex
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/openGemini/openGemini/open_src/influx/influxql"
+)
+
+// literalFieldRef builds a standalone single-value FieldRef from an influxql
+// literal, for comparing against per-granule stats outside of a KeyCondition.
+func literalFieldRef(v influxql.Expr) (*FieldRef, bool) {
+	switch lit := v.(type) {
+	case *influxql.StringLiteral:
+		return newStringFieldRef(lit.Val), true
+	case *influxql.NumberLiteral:
+		return newFloatFieldRef(lit.Val), true
+	case *influxql.IntegerLiteral:
+		return newIntegerFieldRef(lit.Val), true
+	default:
+		return nil, false
+	}
+}
+
+// literalBytes encodes an influxql literal as bytes suitable for hashing
+// into a bloom filter.
+func literalBytes(v influxql.Expr) ([]byte, bool) {
+	switch lit := v.(type) {
+	case *influxql.StringLiteral:
+		return []byte(lit.Val), true
+	case *influxql.IntegerLiteral:
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, uint64(lit.Val))
+		return b, true
+	case *influxql.NumberLiteral:
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, math.Float64bits(lit.Val))
+		return b, true
+	case *influxql.BooleanLiteral:
+		if lit.Val {
+			return []byte{1}, true
+		}
+		return []byte{0}, true
+	default:
+		return nil, false
+	}
+}
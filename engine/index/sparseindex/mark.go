@@ -0,0 +1,60 @@
+/*
+Copyright 2023 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sparseindex
+
+// Mark records whether a granule can possibly satisfy a condition (canBeTrue)
+// and whether it can possibly fail to satisfy it (canBeFalse). Both bits can be
+// true at once, meaning the condition is not decidable from the range alone.
+type Mark struct {
+	canBeTrue  bool
+	canBeFalse bool
+}
+
+// ConsiderOnlyBeTrue is the neutral element used to seed an OR-reduction over
+// sub-hyper-rectangles: nothing has been proven true yet, and falseness is
+// assumed possible until a sub-rectangle says otherwise.
+var ConsiderOnlyBeTrue = NewMark(false, true)
+
+func NewMark(canBeTrue, canBeFalse bool) Mark {
+	return Mark{canBeTrue: canBeTrue, canBeFalse: canBeFalse}
+}
+
+func (m Mark) Not() Mark {
+	return Mark{canBeTrue: m.canBeFalse, canBeFalse: m.canBeTrue}
+}
+
+func (m Mark) And(o Mark) Mark {
+	return Mark{canBeTrue: m.canBeTrue && o.canBeTrue, canBeFalse: m.canBeFalse || o.canBeFalse}
+}
+
+func (m Mark) Or(o Mark) Mark {
+	return Mark{canBeTrue: m.canBeTrue || o.canBeTrue, canBeFalse: m.canBeFalse && o.canBeFalse}
+}
+
+// isComplete reports whether further sub-rectangles cannot change the result,
+// i.e. both canBeTrue and canBeFalse are already set.
+func (m Mark) isComplete() bool {
+	return m.canBeTrue && m.canBeFalse
+}
+
+func (m Mark) CanBeTrue() bool {
+	return m.canBeTrue
+}
+
+func (m Mark) CanBeFalse() bool {
+	return m.canBeFalse
+}
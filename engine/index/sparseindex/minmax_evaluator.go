@@ -0,0 +1,101 @@
+/*
+Copyright 2023 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sparseindex
+
+import (
+	"github.com/openGemini/openGemini/lib/record"
+	"github.com/openGemini/openGemini/open_src/influx/influxql"
+)
+
+// MinMaxEvaluator is a SkipIndexEvaluator over the per-granule min/max stats
+// every column already carries. It claims any single comparison on a schema
+// column, primary-key or not.
+type MinMaxEvaluator struct {
+	colIdx int
+	op     influxql.Token
+	value  influxql.Expr
+}
+
+func NewMinMaxEvaluator() *MinMaxEvaluator {
+	return &MinMaxEvaluator{}
+}
+
+func (e *MinMaxEvaluator) Name() string {
+	return "minmax"
+}
+
+func (e *MinMaxEvaluator) Prepare(cond influxql.Expr, schema record.Schemas) (SkipIndexEvaluator, error) {
+	bin, ok := cond.(*influxql.BinaryExpr)
+	if !ok {
+		return nil, nil
+	}
+	switch bin.Op {
+	case influxql.EQ, influxql.NEQ, influxql.LT, influxql.LTE, influxql.GT, influxql.GTE:
+	default:
+		return nil, nil
+	}
+	ref, ok := bin.LHS.(*influxql.VarRef)
+	if !ok {
+		return nil, nil
+	}
+	idx := schema.FieldIndex(ref.Val)
+	if idx < 0 {
+		return nil, nil
+	}
+	return &MinMaxEvaluator{colIdx: idx, op: bin.Op, value: bin.RHS}, nil
+}
+
+func (e *MinMaxEvaluator) MayContain(stats GranuleStats) (Mark, error) {
+	if e.colIdx >= len(stats.Min) || e.colIdx >= len(stats.Max) ||
+		stats.Min[e.colIdx] == nil || stats.Max[e.colIdx] == nil {
+		return NewMark(true, true), nil
+	}
+	lit, ok := literalFieldRef(e.value)
+	if !ok {
+		return NewMark(true, true), nil
+	}
+
+	minMax := NewRange(stats.Min[e.colIdx], stats.Max[e.colIdx], true, true)
+	var valueRange *Range
+	switch e.op {
+	case influxql.EQ:
+		valueRange = NewRange(lit, lit, true, true)
+	case influxql.LT:
+		valueRange = createRightBounded(lit, false, false)
+	case influxql.LTE:
+		valueRange = createRightBounded(lit, true, false)
+	case influxql.GT:
+		valueRange = createLeftBounded(lit, false, false)
+	case influxql.GTE:
+		valueRange = createLeftBounded(lit, true, false)
+	default:
+		// NEQ can only rule a granule out when min == max == the excluded
+		// value, which containsRange/intersectsRange can't express; stay
+		// conservative rather than risk pruning a matching row.
+		return NewMark(true, true), nil
+	}
+
+	if !minMax.intersectsRange(valueRange) {
+		return NewMark(false, true), nil
+	}
+	// canBeFalse is false (every row must satisfy the comparison) only when
+	// minMax itself is fully inside valueRange, not the other way around:
+	// for EQ, valueRange is the single point lit, and minMax.containsRange
+	// would ask whether lit falls inside [min,max], which is true whenever
+	// the granule merely intersects lit rather than being pinned to it.
+	return NewMark(true, !valueRange.containsRange(minMax)), nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2023 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sparseindex
+
+import (
+	"github.com/openGemini/openGemini/open_src/influx/influxql"
+)
+
+// normalizeNot rewrites expr into an equivalent form with every NOT pushed
+// down to its comparison leaves and eliminated, using De Morgan's laws for
+// AND/OR and flipping comparison operators. rpn.ConvertToRPNExpr has no
+// notion of NOT, so KeyCondition must never hand it an expression that still
+// contains one; a NOT over something that can't be flipped (e.g. a LIKE or
+// an opaque function call) is left in place and reported by the caller.
+func normalizeNot(expr influxql.Expr) influxql.Expr {
+	return pushNot(expr, false)
+}
+
+// pushNot returns the equivalent of expr, negated if negated is true.
+func pushNot(expr influxql.Expr, negated bool) influxql.Expr {
+	switch e := expr.(type) {
+	case *influxql.ParenExpr:
+		return &influxql.ParenExpr{Expr: pushNot(e.Expr, negated)}
+	case *influxql.UnaryExpr:
+		if e.Op == influxql.NOT {
+			return pushNot(e.Expr, !negated)
+		}
+		if negated {
+			return &influxql.UnaryExpr{Op: influxql.NOT, Expr: e}
+		}
+		return e
+	case *influxql.BinaryExpr:
+		if e.Op == influxql.AND || e.Op == influxql.OR {
+			op := e.Op
+			if negated {
+				if op == influxql.AND {
+					op = influxql.OR
+				} else {
+					op = influxql.AND
+				}
+			}
+			return &influxql.BinaryExpr{Op: op, LHS: pushNot(e.LHS, negated), RHS: pushNot(e.RHS, negated)}
+		}
+		if !negated {
+			return e
+		}
+		flipped, ok := flipComparisonToken(e.Op)
+		if !ok {
+			// No negation for this operator (e.g. LIKE, EQREGEX): leave the
+			// NOT in place, containsNot will catch it and NewKeyCondition
+			// will reject the condition with a clear error.
+			return &influxql.UnaryExpr{Op: influxql.NOT, Expr: e}
+		}
+		return &influxql.BinaryExpr{Op: flipped, LHS: e.LHS, RHS: e.RHS}
+	default:
+		if negated {
+			return &influxql.UnaryExpr{Op: influxql.NOT, Expr: expr}
+		}
+		return expr
+	}
+}
+
+// flipComparisonToken returns the operator whose result is the logical
+// negation of op, for the comparison operators KeyCondition understands.
+func flipComparisonToken(op influxql.Token) (influxql.Token, bool) {
+	switch op {
+	case influxql.EQ:
+		return influxql.NEQ, true
+	case influxql.NEQ:
+		return influxql.EQ, true
+	case influxql.LT:
+		return influxql.GTE, true
+	case influxql.LTE:
+		return influxql.GT, true
+	case influxql.GT:
+		return influxql.LTE, true
+	case influxql.GTE:
+		return influxql.LT, true
+	case influxql.IN:
+		return influxql.NIN, true
+	case influxql.NIN:
+		return influxql.IN, true
+	default:
+		return 0, false
+	}
+}
+
+// containsNot reports whether expr still has a NOT that normalizeNot
+// couldn't eliminate.
+func containsNot(expr influxql.Expr) bool {
+	switch e := expr.(type) {
+	case *influxql.UnaryExpr:
+		return e.Op == influxql.NOT || containsNot(e.Expr)
+	case *influxql.ParenExpr:
+		return containsNot(e.Expr)
+	case *influxql.BinaryExpr:
+		return containsNot(e.LHS) || containsNot(e.RHS)
+	default:
+		return false
+	}
+}
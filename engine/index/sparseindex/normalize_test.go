@@ -0,0 +1,161 @@
+/*
+Copyright 2023 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sparseindex
+
+import (
+	"testing"
+
+	"github.com/openGemini/openGemini/lib/record"
+	"github.com/openGemini/openGemini/open_src/influx/influxql"
+	"github.com/openGemini/openGemini/open_src/vm/protoparser/influx"
+)
+
+func abGtLt(op1 influxql.Token, lhs1 string, rhs1 int64, op2 influxql.Token, lhs2 string, rhs2 int64) *influxql.BinaryExpr {
+	return &influxql.BinaryExpr{
+		Op: influxql.AND,
+		LHS: &influxql.BinaryExpr{
+			Op:  op1,
+			LHS: &influxql.VarRef{Val: lhs1},
+			RHS: &influxql.IntegerLiteral{Val: rhs1},
+		},
+		RHS: &influxql.BinaryExpr{
+			Op:  op2,
+			LHS: &influxql.VarRef{Val: lhs2},
+			RHS: &influxql.IntegerLiteral{Val: rhs2},
+		},
+	}
+}
+
+// TestNormalizeNot_DeMorgan checks that NOT (a > 5 AND b < 3) prunes
+// identically to its hand-normalized De Morgan form, a <= 5 OR b >= 3, across
+// a range of granules, including ones whose key range straddles the literal
+// so neither side can resolve to a single point.
+func TestNormalizeNot_DeMorgan(t *testing.T) {
+	pkSchema := record.Schemas{
+		{Name: "a", Type: influx.Field_Type_Int},
+		{Name: "b", Type: influx.Field_Type_Int},
+	}
+	dataTypes := []int{influx.Field_Type_Int, influx.Field_Type_Int}
+
+	notExpr := &influxql.UnaryExpr{
+		Op:   influxql.NOT,
+		Expr: &influxql.ParenExpr{Expr: abGtLt(influxql.GT, "a", 5, influxql.LT, "b", 3)},
+	}
+	normalizedExpr := &influxql.BinaryExpr{
+		Op: influxql.OR,
+		LHS: &influxql.BinaryExpr{
+			Op: influxql.LTE, LHS: &influxql.VarRef{Val: "a"}, RHS: &influxql.IntegerLiteral{Val: 5},
+		},
+		RHS: &influxql.BinaryExpr{
+			Op: influxql.GTE, LHS: &influxql.VarRef{Val: "b"}, RHS: &influxql.IntegerLiteral{Val: 3},
+		},
+	}
+
+	kcNot, err := NewKeyCondition(nil, notExpr, pkSchema)
+	if err != nil {
+		t.Fatalf("NewKeyCondition(NOT form) returned error: %v", err)
+	}
+	kcNormalized, err := NewKeyCondition(nil, normalizedExpr, pkSchema)
+	if err != nil {
+		t.Fatalf("NewKeyCondition(hand-normalized form) returned error: %v", err)
+	}
+
+	granules := []struct {
+		name          string
+		aLeft, aRight int64
+		bLeft, bRight int64
+	}{
+		{"both single points, outside NOT(AND)", 10, 10, 1, 1},
+		{"both single points, inside NOT(AND)", 1, 1, 1, 1},
+		{"a straddles the literal", 1, 10, 1, 1},
+		{"b straddles the literal", 10, 10, 1, 4},
+		{"both straddle the literal", 1, 10, 1, 4},
+	}
+	for _, g := range granules {
+		t.Run(g.name, func(t *testing.T) {
+			leftKeys := []*FieldRef{newIntegerFieldRef(g.aLeft), newIntegerFieldRef(g.bLeft)}
+			rightKeys := []*FieldRef{newIntegerFieldRef(g.aRight), newIntegerFieldRef(g.bRight)}
+
+			gotNot, err := kcNot.MayBeInRange(2, leftKeys, rightKeys, dataTypes)
+			if err != nil {
+				t.Fatalf("MayBeInRange(NOT form) returned error: %v", err)
+			}
+			gotNormalized, err := kcNormalized.MayBeInRange(2, leftKeys, rightKeys, dataTypes)
+			if err != nil {
+				t.Fatalf("MayBeInRange(hand-normalized form) returned error: %v", err)
+			}
+			if gotNot != gotNormalized {
+				t.Errorf("MayBeInRange mismatch: NOT form = %v, hand-normalized form = %v", gotNot, gotNormalized)
+			}
+		})
+	}
+}
+
+// TestNormalizeNot_DoubleNegation checks that NOT NOT (a > 5) prunes
+// identically to the bare a > 5.
+func TestNormalizeNot_DoubleNegation(t *testing.T) {
+	pkSchema := record.Schemas{{Name: "a", Type: influx.Field_Type_Int}}
+	dataTypes := []int{influx.Field_Type_Int}
+
+	gt5 := &influxql.BinaryExpr{Op: influxql.GT, LHS: &influxql.VarRef{Val: "a"}, RHS: &influxql.IntegerLiteral{Val: 5}}
+	doubleNot := &influxql.UnaryExpr{Op: influxql.NOT, Expr: &influxql.UnaryExpr{Op: influxql.NOT, Expr: gt5}}
+
+	kcDoubleNot, err := NewKeyCondition(nil, doubleNot, pkSchema)
+	if err != nil {
+		t.Fatalf("NewKeyCondition(double NOT) returned error: %v", err)
+	}
+	kcPlain, err := NewKeyCondition(nil, gt5, pkSchema)
+	if err != nil {
+		t.Fatalf("NewKeyCondition(a > 5) returned error: %v", err)
+	}
+
+	for _, v := range []int64{0, 5, 6, 100} {
+		leftKeys := []*FieldRef{newIntegerFieldRef(v)}
+		rightKeys := []*FieldRef{newIntegerFieldRef(v)}
+
+		gotDoubleNot, err := kcDoubleNot.MayBeInRange(1, leftKeys, rightKeys, dataTypes)
+		if err != nil {
+			t.Fatalf("MayBeInRange(double NOT) returned error: %v", err)
+		}
+		gotPlain, err := kcPlain.MayBeInRange(1, leftKeys, rightKeys, dataTypes)
+		if err != nil {
+			t.Fatalf("MayBeInRange(a > 5) returned error: %v", err)
+		}
+		if gotDoubleNot != gotPlain {
+			t.Errorf("a=%d: MayBeInRange mismatch: double NOT = %v, plain = %v", v, gotDoubleNot, gotPlain)
+		}
+	}
+}
+
+// TestNewKeyCondition_ResidualNotRejected checks that a NOT wrapping a
+// predicate flipComparisonToken can't flip, such as LIKE, is rejected with an
+// error instead of silently reaching rpn.ConvertToRPNExpr, which has no NOT
+// semantics.
+func TestNewKeyCondition_ResidualNotRejected(t *testing.T) {
+	pkSchema := record.Schemas{{Name: "name", Type: influx.Field_Type_String}}
+
+	likeExpr := &influxql.BinaryExpr{
+		Op:  influxql.LIKE,
+		LHS: &influxql.VarRef{Val: "name"},
+		RHS: &influxql.StringLiteral{Val: "foo%"},
+	}
+	notLike := &influxql.UnaryExpr{Op: influxql.NOT, Expr: likeExpr}
+
+	if _, err := NewKeyCondition(nil, notLike, pkSchema); err == nil {
+		t.Fatal("NewKeyCondition(NOT (name LIKE 'foo%')) returned no error, want a residual-NOT error")
+	}
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2023 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sparseindex
+
+import (
+	"strings"
+
+	"github.com/openGemini/openGemini/lib/errno"
+	"github.com/openGemini/openGemini/lib/rpn"
+	"github.com/openGemini/openGemini/open_src/influx/influxql"
+)
+
+// handleStartsWith recognizes `starts_with(col, 'prefix')` used directly as a
+// boolean predicate and, when col is a primary-key column, synthesizes a
+// prefix range element for it. It returns false when the call isn't over a
+// primary-key column, in which case the caller treats it like any other
+// opaque filter.
+func (kc *KeyConditionImpl) handleStartsWith(call *influxql.Call, cols []*ColumnRef) (bool, error) {
+	if len(call.Args) != 2 {
+		return false, nil
+	}
+	ref, ok := call.Args[0].(*influxql.VarRef)
+	if !ok {
+		return false, nil
+	}
+	idx := kc.pkSchema.FieldIndex(ref.Val)
+	if idx < 0 {
+		return false, nil
+	}
+	lit, ok := call.Args[1].(*influxql.StringLiteral)
+	if !ok {
+		return false, errno.NewError(errno.ErrRPNElement, call)
+	}
+	kc.genRPNElementForPrefix(idx, lit.Val, false, cols)
+	return true, nil
+}
+
+// genRPNElementForPattern handles a LIKE/regex comparison on a primary-key
+// column by extracting the pattern's fixed literal prefix, if any, and
+// synthesizing a prefix range element for it.
+func (kc *KeyConditionImpl) genRPNElementForPattern(idx int, value interface{}, op influxql.Token, cols []*ColumnRef) error {
+	var prefix string
+	var hasResidual bool
+	switch op {
+	case influxql.EQREGEX:
+		re, ok := value.(*influxql.RegexLiteral)
+		if !ok {
+			return errno.NewError(errno.ErrRPNElement, value)
+		}
+		prefix, hasResidual = extractRegexPrefix(re.Val.String())
+	case influxql.LIKE:
+		lit, ok := value.(*influxql.StringLiteral)
+		if !ok {
+			return errno.NewError(errno.ErrRPNElement, value)
+		}
+		prefix, hasResidual = extractLikePrefix(lit.Val)
+	default:
+		return errno.NewError(errno.ErrRPNOp, op)
+	}
+	if prefix == "" {
+		// No usable literal prefix (e.g. the pattern starts with a
+		// wildcard): there's nothing to prune on, but an AND/OR sibling
+		// still expects this conjunct to push exactly one Mark onto the
+		// stack, so emit a whole-range element rather than silently
+		// dropping it (dropping it would underflow the stack at evaluation
+		// time). The caller's residual filter does the actual matching.
+		kc.genRPNElementForWholeRange(idx)
+		return nil
+	}
+	kc.genRPNElementForPrefix(idx, prefix, hasResidual, cols)
+	return nil
+}
+
+// genRPNElementForWholeRange appends an always-true, whole-range element for
+// idx, requiring the caller's residual filter to do the actual matching.
+// It's used when a predicate is recognized as being over a primary-key
+// column but no usable range can be derived from it.
+func (kc *KeyConditionImpl) genRPNElementForWholeRange(idx int) {
+	kc.rpn = append(kc.rpn, &RPNElement{
+		op:                     rpn.InRange,
+		keyColumn:              idx,
+		rg:                     createWholeRangeWithoutBound(),
+		residualFilterRequired: true,
+	})
+}
+
+// genRPNElementForPrefix builds the `[prefix, successor(prefix))` range
+// element that prunes granules whose sorted key can't contain any value
+// starting with prefix.
+func (kc *KeyConditionImpl) genRPNElementForPrefix(idx int, prefix string, hasResidual bool, cols []*ColumnRef) {
+	lowerRow := appendStringLiteral(cols, idx, prefix)
+	lower := NewFieldRef(cols, idx, lowerRow)
+
+	rpnElem := &RPNElement{op: rpn.InRange, keyColumn: idx, residualFilterRequired: hasResidual}
+	if succ, ok := successorString(prefix); ok {
+		upperRow := appendStringLiteral(cols, idx, succ)
+		rpnElem.rg = NewRange(lower, NewFieldRef(cols, idx, upperRow), true, false)
+	} else {
+		// prefix is all 0xFF bytes: there is no string successor, so the
+		// range can only be bounded on the left.
+		rpnElem.rg = createLeftBounded(lower, true, false)
+	}
+	kc.rpn = append(kc.rpn, rpnElem)
+}
+
+func appendStringLiteral(cols []*ColumnRef, idx int, s string) int {
+	col := cols[idx]
+	row := col.column.Len
+	col.column.AppendString(s)
+	return row
+}
+
+// successorString returns the smallest string strictly greater than every
+// string with p as a prefix, by incrementing p's last byte and carrying
+// through any trailing 0xFF bytes. It reports false when p is empty or made
+// entirely of 0xFF bytes, in which case no such successor exists.
+func successorString(p string) (string, bool) {
+	b := []byte(p)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] != 0xFF {
+			b[i]++
+			return string(b[:i+1]), true
+		}
+	}
+	return "", false
+}
+
+// extractRegexPrefix pulls the fixed literal prefix off the front of a regex
+// pattern, e.g. "^foo.*" -> ("foo", true). It only does this for patterns
+// anchored with a leading '^': an unanchored pattern like "foo" can match
+// anywhere in the string (e.g. "aafoo"), so there is no literal prefix a
+// sorted key range could be pruned on, and it returns ("", false). hasResidual
+// is true whenever the pattern has anything left after the prefix, since a
+// range alone can't prove a regex match. A pattern containing '|' anywhere
+// also returns ("", false), since "^foo|bar" anchors only its first branch,
+// and a backslash escape counts as a literal byte only for punctuation (\d,
+// \w, \b and the like end the prefix).
+func extractRegexPrefix(pattern string) (string, bool) {
+	if len(pattern) == 0 || pattern[0] != '^' || strings.IndexByte(pattern, '|') >= 0 {
+		return "", false
+	}
+	i := 1
+	prefix := make([]byte, 0, len(pattern))
+	for ; i < len(pattern); i++ {
+		c := pattern[i]
+		if c == '\\' && i+1 < len(pattern) {
+			e := pattern[i+1]
+			if (e >= '0' && e <= '9') || (e >= 'a' && e <= 'z') || (e >= 'A' && e <= 'Z') {
+				return string(prefix), true
+			}
+			i++
+			prefix = append(prefix, e)
+			continue
+		}
+		if isRegexMeta(c) {
+			if isOptionalQuantifier(c) && len(prefix) > 0 {
+				// '*', '?' and '{' make the byte immediately before them
+				// optional (e.g. "foo*" also matches "fo"), so that byte
+				// isn't actually guaranteed by the prefix; '+' requires at
+				// least one repetition and so doesn't have this problem.
+				prefix = prefix[:len(prefix)-1]
+			}
+			return string(prefix), true
+		}
+		prefix = append(prefix, c)
+	}
+	return string(prefix), false
+}
+
+// isOptionalQuantifier reports whether c is a quantifier meta character that
+// makes the preceding literal byte optional rather than mandatory.
+func isOptionalQuantifier(c byte) bool {
+	switch c {
+	case '*', '?', '{':
+		return true
+	}
+	return false
+}
+
+func isRegexMeta(c byte) bool {
+	switch c {
+	case '.', '*', '+', '?', '(', ')', '[', ']', '{', '}', '|', '^', '$':
+		return true
+	}
+	return false
+}
+
+// extractLikePrefix pulls the fixed literal prefix off the front of a LIKE
+// pattern, stopping at the first `%` (any sequence) or `_` (single char)
+// wildcard, e.g. "foo%" -> ("foo", true). A pattern with no wildcard matches
+// only itself, which its [pattern, successor) range does not prove, so it is
+// returned whole with hasResidual true as well, e.g. "foo" -> ("foo", true).
+func extractLikePrefix(pattern string) (string, bool) {
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '%' || pattern[i] == '_' {
+			return pattern[:i], true
+		}
+	}
+	return pattern, true
+}
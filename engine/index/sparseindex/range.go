@@ -0,0 +1,178 @@
+/*
+Copyright 2023 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sparseindex
+
+import (
+	"github.com/openGemini/openGemini/lib/record"
+)
+
+// ColumnRef holds the backing column for a single primary-key field so that
+// constant values extracted from a predicate can be compared against the
+// statistics gathered for a granule.
+type ColumnRef struct {
+	column *record.ColVal
+}
+
+// genIndexColumnsBySchema allocates one ColumnRef per primary-key column so
+// literal values parsed out of the condition can be appended to them.
+func genIndexColumnsBySchema(pkSchema record.Schemas) []*ColumnRef {
+	cols := make([]*ColumnRef, len(pkSchema))
+	for i := range pkSchema {
+		cols[i] = &ColumnRef{column: &record.ColVal{}}
+	}
+	return cols
+}
+
+// FieldRef points at a single value of a single primary-key column, i.e. one
+// literal extracted from the query condition.
+type FieldRef struct {
+	cols []*ColumnRef
+	idx  int
+	row  int
+}
+
+func NewFieldRef(cols []*ColumnRef, idx, row int) *FieldRef {
+	return &FieldRef{cols: cols, idx: idx, row: row}
+}
+
+func (f *FieldRef) Equals(o *FieldRef) bool {
+	if f == nil || o == nil {
+		return f == o
+	}
+	return compareFieldRef(f, o) == 0
+}
+
+func (f *FieldRef) Less(o *FieldRef) bool {
+	return compareFieldRef(f, o) < 0
+}
+
+func (f *FieldRef) IntegerValue() (int64, bool) {
+	return f.cols[f.idx].column.IntegerValue(f.row)
+}
+
+func (f *FieldRef) FloatValue() (float64, bool) {
+	return f.cols[f.idx].column.FloatValue(f.row)
+}
+
+func (f *FieldRef) StringValue() (string, bool) {
+	return f.cols[f.idx].column.StringValue(f.row)
+}
+
+// newIntegerFieldRef, newFloatFieldRef and newStringFieldRef build a
+// single-value FieldRef holding a literal, used by FunctionBase.Apply
+// implementations to construct the output bound of a mapped range.
+func newIntegerFieldRef(v int64) *FieldRef {
+	col := &ColumnRef{column: &record.ColVal{}}
+	col.column.AppendInteger(v)
+	return NewFieldRef([]*ColumnRef{col}, 0, 0)
+}
+
+func newFloatFieldRef(v float64) *FieldRef {
+	col := &ColumnRef{column: &record.ColVal{}}
+	col.column.AppendFloat(v)
+	return NewFieldRef([]*ColumnRef{col}, 0, 0)
+}
+
+func newStringFieldRef(v string) *FieldRef {
+	col := &ColumnRef{column: &record.ColVal{}}
+	col.column.AppendString(v)
+	return NewFieldRef([]*ColumnRef{col}, 0, 0)
+}
+
+// Range is a closed/half-open/open interval over a single primary-key column,
+// used as one edge of the hyper-rectangle that CheckInRange intersects
+// against the predicate's own ranges.
+type Range struct {
+	left         *FieldRef
+	right        *FieldRef
+	includeLeft  bool
+	includeRight bool
+}
+
+func NewRange(left, right *FieldRef, includeLeft, includeRight bool) *Range {
+	return &Range{left: left, right: right, includeLeft: includeLeft, includeRight: includeRight}
+}
+
+func createLeftBounded(left *FieldRef, includeLeft, unknown bool) *Range {
+	return &Range{left: left, includeLeft: includeLeft, includeRight: unknown}
+}
+
+func createRightBounded(right *FieldRef, includeRight, unknown bool) *Range {
+	return &Range{right: right, includeRight: includeRight, includeLeft: unknown}
+}
+
+func createWholeRangeIncludeBound() *Range {
+	return &Range{includeLeft: true, includeRight: true}
+}
+
+func createWholeRangeWithoutBound() *Range {
+	return &Range{}
+}
+
+// intersectsRange reports whether the receiver has any point in common with o.
+func (r *Range) intersectsRange(o *Range) bool {
+	if r == nil || o == nil {
+		return true
+	}
+	if r.left != nil && o.right != nil {
+		cmp := compareFieldRef(r.left, o.right)
+		if cmp > 0 || (cmp == 0 && !(r.includeLeft && o.includeRight)) {
+			return false
+		}
+	}
+	if r.right != nil && o.left != nil {
+		cmp := compareFieldRef(r.right, o.left)
+		if cmp < 0 || (cmp == 0 && !(r.includeRight && o.includeLeft)) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsRange reports whether the receiver fully contains o.
+func (r *Range) containsRange(o *Range) bool {
+	if r == nil {
+		return true
+	}
+	if o == nil {
+		return r.left == nil && r.right == nil
+	}
+	if r.left != nil {
+		if o.left == nil {
+			return false
+		}
+		cmp := compareFieldRef(r.left, o.left)
+		if cmp > 0 || (cmp == 0 && !r.includeLeft && o.includeLeft) {
+			return false
+		}
+	}
+	if r.right != nil {
+		if o.right == nil {
+			return false
+		}
+		cmp := compareFieldRef(r.right, o.right)
+		if cmp < 0 || (cmp == 0 && !r.includeRight && o.includeRight) {
+			return false
+		}
+	}
+	return true
+}
+
+func compareFieldRef(a, b *FieldRef) int {
+	colA, colB := a.cols[a.idx].column, b.cols[b.idx].column
+	return colA.Compare(colB, a.row, b.row)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2023 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sparseindex
+
+import "sort"
+
+// PKSetIndex is the SetIndex used for `IN (...)` / `NOT IN (...)` predicates
+// over one or more primary-key columns. Rather than expanding the list into
+// ORed equalities, it keeps the tuples sorted in primary-key column order and
+// binary-searches them for the first candidate, so a single-column check is
+// O(log N) however long the list is; with several key columns it then scans
+// forward while the leading column stays within the range.
+type PKSetIndex struct {
+	// keyColumns holds the pk schema index each tuple position corresponds
+	// to, in ascending order, e.g. [0, 2] for `(c, a) IN (...)` over a
+	// schema (a, b, c).
+	keyColumns []int
+
+	// tuples is sorted ascending by keyColumns order. Each entry has one
+	// FieldRef per keyColumns position.
+	tuples [][]*FieldRef
+}
+
+// NewPKSetIndex builds a PKSetIndex over tuples. It reorders each tuple's
+// positions into ascending primary-key column order and then sorts the
+// tuples, both in place.
+func NewPKSetIndex(keyColumns []int, tuples [][]*FieldRef) *PKSetIndex {
+	perm := make([]int, len(keyColumns))
+	for i := range perm {
+		perm[i] = i
+	}
+	sort.SliceStable(perm, func(i, j int) bool { return keyColumns[perm[i]] < keyColumns[perm[j]] })
+	cols := make([]int, len(perm))
+	for i, p := range perm {
+		cols[i] = keyColumns[p]
+	}
+	for t, tuple := range tuples {
+		reordered := make([]*FieldRef, len(perm))
+		for i, p := range perm {
+			reordered[i] = tuple[p]
+		}
+		tuples[t] = reordered
+	}
+	sort.Slice(tuples, func(i, j int) bool {
+		return compareTuple(tuples[i], tuples[j]) < 0
+	})
+	return &PKSetIndex{keyColumns: cols, tuples: tuples}
+}
+
+func compareTuple(a, b []*FieldRef) int {
+	for i := range a {
+		if c := compareFieldRef(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func (idx *PKSetIndex) Not() bool {
+	return len(idx.tuples) == 0
+}
+
+// checkInRange reports whether any tuple in the set falls inside the
+// hyper-rectangle described by rgs (and, symmetrically, whether any tuple
+// might fall outside it), by binary-searching for the first tuple that is
+// not below the range on the leading key column and scanning forward only
+// while that column stays within bounds.
+func (idx *PKSetIndex) checkInRange(rgs []*Range, dataTypes []int, singlePoint bool) Mark {
+	if len(idx.tuples) == 0 {
+		return NewMark(false, true)
+	}
+
+	lead := idx.keyColumns[0]
+	leadRange := rgs[lead]
+	start := sort.Search(len(idx.tuples), func(i int) bool {
+		if leadRange.left == nil {
+			return true
+		}
+		return compareFieldRef(idx.tuples[i][0], leadRange.left) >= 0
+	})
+
+	canBeTrue := false
+	for i := start; i < len(idx.tuples); i++ {
+		tuple := idx.tuples[i]
+		if leadRange.right != nil && compareFieldRef(tuple[0], leadRange.right) > 0 {
+			break
+		}
+		if tupleInRanges(tuple, idx.keyColumns, rgs) {
+			canBeTrue = true
+			break
+		}
+	}
+
+	// A whole-tuple match only rules out "false" when the range has
+	// collapsed to that single point; otherwise other rows in the range
+	// could still fail the predicate.
+	canBeFalse := !(canBeTrue && singlePoint)
+	return NewMark(canBeTrue, canBeFalse)
+}
+
+func tupleInRanges(tuple []*FieldRef, keyColumns []int, rgs []*Range) bool {
+	for i, col := range keyColumns {
+		rg := rgs[col]
+		v := tuple[i]
+		if rg.left != nil && compareFieldRef(v, rg.left) < 0 {
+			return false
+		}
+		if rg.right != nil && compareFieldRef(v, rg.right) > 0 {
+			return false
+		}
+	}
+	return true
+}
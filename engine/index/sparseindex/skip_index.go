@@ -0,0 +1,125 @@
+/*
+Copyright 2023 Huawei Cloud Computing Technologies Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sparseindex
+
+import (
+	"github.com/openGemini/openGemini/lib/record"
+	"github.com/openGemini/openGemini/open_src/influx/influxql"
+)
+
+// SkipIndexEvaluator lets a secondary index participate in granule pruning
+// alongside KeyCondition without KeyCondition knowing anything about it.
+// A prototype instance is registered with RegisterSkipIndexEvaluator;
+// MayContainGranule calls Prepare on every registered prototype with each
+// top-level AND conjunct of a scan's condition and the schema, and ANDs
+// together the Mark of whichever
+// evaluators claim it with the Mark from KeyCondition.MayBeInRange.
+type SkipIndexEvaluator interface {
+	// Name identifies the evaluator, e.g. for logging or disabling it.
+	Name() string
+
+	// Prepare binds the evaluator to cond and schema for one scan. It
+	// returns a nil evaluator (and nil error) when cond isn't something
+	// this evaluator can prune on, so the caller skips it.
+	Prepare(cond influxql.Expr, schema record.Schemas) (SkipIndexEvaluator, error)
+
+	// MayContain reports whether a granule with the given stats could
+	// possibly satisfy the condition this evaluator was prepared with.
+	MayContain(stats GranuleStats) (Mark, error)
+}
+
+// GranuleStats is the per-granule summary data secondary indexes evaluate
+// predicates against: min/max bounds per schema column, aligned to schema
+// order, and any per-column bloom filter footers available for equality
+// pruning, keyed by column name.
+type GranuleStats struct {
+	Min   []*FieldRef
+	Max   []*FieldRef
+	Bloom map[string][]byte
+}
+
+var skipIndexRegistry []SkipIndexEvaluator
+
+// RegisterSkipIndexEvaluator adds a prototype evaluator that MayContainGranule
+// will offer every scan's condition to via Prepare. Downstream users add
+// domain-specific indexes (HLL, set-membership dictionaries, ...) by calling
+// this from an init() in the package that implements them, without touching
+// sparseindex itself.
+func RegisterSkipIndexEvaluator(proto SkipIndexEvaluator) {
+	skipIndexRegistry = append(skipIndexRegistry, proto)
+}
+
+func init() {
+	RegisterSkipIndexEvaluator(NewMinMaxEvaluator())
+	RegisterSkipIndexEvaluator(NewBloomFilterEvaluator())
+}
+
+// MayContainGranule ANDs kc.MayBeInRange with the Mark from every registered
+// SkipIndexEvaluator that claims one of cond's top-level AND conjuncts,
+// short-circuiting as soon as any of them rules the granule out. Each
+// SkipIndexEvaluator.Prepare only ever claims a single comparison, but a
+// realistic scan condition is almost always `a = 1 AND b = 2 AND ...`, not a
+// bare comparison, so cond is split into its conjuncts before offering them
+// to Prepare.
+func MayContainGranule(
+	kc KeyCondition,
+	usedKeySize int,
+	leftKeys, rightKeys []*FieldRef,
+	dataTypes []int,
+	cond influxql.Expr,
+	schema record.Schemas,
+	stats GranuleStats,
+) (bool, error) {
+	ok, err := kc.MayBeInRange(usedKeySize, leftKeys, rightKeys, dataTypes)
+	if err != nil || !ok {
+		return false, err
+	}
+	for _, conjunct := range splitConjuncts(cond) {
+		for _, proto := range skipIndexRegistry {
+			evalr, err := proto.Prepare(conjunct, schema)
+			if err != nil {
+				return false, err
+			}
+			if evalr == nil {
+				continue
+			}
+			mark, err := evalr.MayContain(stats)
+			if err != nil {
+				return false, err
+			}
+			if !mark.CanBeTrue() {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// splitConjuncts flattens the top-level AND tree of cond into its leaf
+// conjuncts. A conjunct that isn't itself an AND (including an OR, or a bare
+// comparison) is returned as a single-element slice.
+func splitConjuncts(cond influxql.Expr) []influxql.Expr {
+	switch e := cond.(type) {
+	case *influxql.ParenExpr:
+		return splitConjuncts(e.Expr)
+	case *influxql.BinaryExpr:
+		if e.Op == influxql.AND {
+			return append(splitConjuncts(e.LHS), splitConjuncts(e.RHS)...)
+		}
+	}
+	return []influxql.Expr{cond}
+}